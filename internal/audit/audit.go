@@ -0,0 +1,65 @@
+// Package audit provides a pluggable sink for structured records of every
+// bashbot command invocation, so operators can feed them into a SIEM or
+// search them during a post-mortem instead of scrolling free-form slack
+// messages.
+package audit
+
+import (
+	"fmt"
+	"time"
+)
+
+// Event is a single structured record of a bashbot command invocation.
+type Event struct {
+	Time        time.Time
+	UserID      string
+	UserName    string
+	UserEmail   string
+	ChannelID   string
+	ChannelName string
+	Tool        string
+	RawArgs     string
+	ResolvedCmd string
+	ExitCode    int
+	Duration    time.Duration
+	// OutputHash is a sha256 of the command's stdout and stderr joined
+	// by a newline, or of the error message when the command failed to
+	// run. Events recorded without running the command (an unauthorized
+	// channel, an invocation just received) hash a short label instead.
+	OutputHash string
+}
+
+// Logger emits an Event to wherever the configured backend sends it.
+type Logger interface {
+	Log(event Event) error
+}
+
+// MessageSender is the subset of slack.Client the "slack" backend needs
+// to deliver an audit event to a channel.
+type MessageSender interface {
+	SendMessageToChannel(channel, msg string)
+}
+
+// Config is the YAML configuration for the audit log sink.
+type Config struct {
+	Backend    string `yaml:"backend"` // "slack" (default), "file" or "webhook"
+	ChannelID  string `yaml:"channel_id"`
+	FilePath   string `yaml:"file_path"`
+	MaxFileMB  int    `yaml:"max_file_mb"`
+	WebhookURL string `yaml:"webhook_url"`
+}
+
+// New builds the Logger configured by cfg. sender is used by the "slack"
+// backend (the default) to deliver events to a slack channel.
+func New(cfg Config, sender MessageSender) (Logger, error) {
+	switch cfg.Backend {
+	case "", "slack":
+		return NewSlackLogger(sender, cfg.ChannelID), nil
+	case "file":
+		return NewFileLogger(cfg.FilePath, cfg.MaxFileMB)
+	case "webhook":
+		return NewWebhookLogger(cfg.WebhookURL), nil
+	default:
+		return nil, fmt.Errorf("unknown audit log backend: %s", cfg.Backend)
+	}
+}
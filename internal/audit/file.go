@@ -0,0 +1,68 @@
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+)
+
+const defaultMaxFileMB = 50
+
+// FileLogger appends each Event as a line of JSON to a local file,
+// rotating the file (renaming it with a ".1" suffix) once it crosses
+// maxFileMB.
+type FileLogger struct {
+	mu       sync.Mutex
+	path     string
+	maxBytes int64
+}
+
+// NewFileLogger returns a FileLogger writing to path, rotating once the
+// file exceeds maxFileMB megabytes. maxFileMB <= 0 uses a 50MB default.
+func NewFileLogger(path string, maxFileMB int) (*FileLogger, error) {
+	if path == "" {
+		return nil, fmt.Errorf("audit file backend requires a file_path")
+	}
+	if maxFileMB <= 0 {
+		maxFileMB = defaultMaxFileMB
+	}
+	return &FileLogger{path: path, maxBytes: int64(maxFileMB) * 1024 * 1024}, nil
+}
+
+// Log appends event as a line of JSON to the configured file, rotating it
+// first if it has grown past the configured size.
+func (l *FileLogger) Log(event Event) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if err := l.rotateIfNeeded(); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(l.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	line, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(append(line, '\n'))
+	return err
+}
+
+func (l *FileLogger) rotateIfNeeded() error {
+	info, err := os.Stat(l.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	if info.Size() < l.maxBytes {
+		return nil
+	}
+	return os.Rename(l.path, l.path+".1")
+}
@@ -0,0 +1,27 @@
+package audit
+
+import "fmt"
+
+// SlackLogger is the default audit backend: it renders each Event as a
+// human-readable line and posts it to a slack channel, the same channel
+// bashbot previously used for ad-hoc log messages.
+type SlackLogger struct {
+	sender    MessageSender
+	channelID string
+}
+
+// NewSlackLogger returns a SlackLogger that posts events to channelID via
+// sender.
+func NewSlackLogger(sender MessageSender, channelID string) *SlackLogger {
+	return &SlackLogger{sender: sender, channelID: channelID}
+}
+
+// Log renders event as a single line and posts it to the configured slack
+// channel.
+func (l *SlackLogger) Log(event Event) error {
+	l.sender.SendMessageToChannel(l.channelID, fmt.Sprintf(
+		"%s <@%s> <#%s> %s (exit %d, %s)",
+		event.Tool, event.UserID, event.ChannelID, event.ResolvedCmd, event.ExitCode, event.Duration,
+	))
+	return nil
+}
@@ -0,0 +1,41 @@
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// WebhookLogger POSTs each Event as JSON to a configured HTTPS endpoint,
+// e.g. a SIEM ingestion webhook.
+type WebhookLogger struct {
+	url    string
+	client *http.Client
+}
+
+// NewWebhookLogger returns a WebhookLogger posting to url.
+func NewWebhookLogger(url string) *WebhookLogger {
+	return &WebhookLogger{
+		url:    url,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Log POSTs event as JSON to the configured webhook URL.
+func (l *WebhookLogger) Log(event Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	resp, err := l.client.Post(l.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("audit webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
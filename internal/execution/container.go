@@ -0,0 +1,45 @@
+package execution
+
+import (
+	"context"
+	"os/exec"
+)
+
+// ContainerSandbox runs a tool's command inside a throwaway container via
+// "docker run --rm" or "podman run --rm" (the two share a CLI).
+type ContainerSandbox struct {
+	Bin string // "docker" or "podman"
+}
+
+// Build assembles a `<Bin> run --rm ...` invocation from cfg: cfg.Image is
+// required, mounts/cpu/memory/network/capabilities/read-only-root are
+// applied as the matching run flags, and env is passed through as -e
+// flags so the TRIGGERED_* vars and the tool's own Envvars reach the
+// container rather than the host shell.
+func (s ContainerSandbox) Build(ctx context.Context, cfg Config, env []string, workdir, shellCmd string) *exec.Cmd {
+	args := []string{"run", "--rm"}
+	if !cfg.Network {
+		args = append(args, "--network", "none")
+	}
+	if cfg.ReadOnlyRoot {
+		args = append(args, "--read-only")
+	}
+	for _, capability := range cfg.DropCaps {
+		args = append(args, "--cap-drop", capability)
+	}
+	if cfg.CPUs != "" {
+		args = append(args, "--cpus", cfg.CPUs)
+	}
+	if cfg.Memory != "" {
+		args = append(args, "--memory", cfg.Memory)
+	}
+	for _, mount := range cfg.Mounts {
+		args = append(args, "-v", mount)
+	}
+	for _, kv := range env {
+		args = append(args, "-e", kv)
+	}
+	args = append(args, "-w", workdir, cfg.Image, "bash", "-c", shellCmd)
+
+	return exec.CommandContext(ctx, s.Bin, args...)
+}
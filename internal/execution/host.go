@@ -0,0 +1,20 @@
+package execution
+
+import (
+	"context"
+	"os"
+	"os/exec"
+)
+
+// HostSandbox runs a command directly on the bashbot host, with no
+// isolation. It's the default, preserving bashbot's pre-sandboxing
+// behavior for tools that don't configure one of the other backends.
+type HostSandbox struct{}
+
+// Build runs shellCmd via "bash -c", the same way bashbot always has.
+func (HostSandbox) Build(ctx context.Context, cfg Config, env []string, workdir, shellCmd string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, "bash", "-c", shellCmd)
+	cmd.Dir = workdir
+	cmd.Env = append(os.Environ(), env...)
+	return cmd
+}
@@ -0,0 +1,54 @@
+package execution
+
+import (
+	"context"
+	"os/exec"
+	"strings"
+)
+
+// NsjailSandbox runs a tool's command under nsjail
+// (https://github.com/google/nsjail), isolating it with Linux namespaces
+// and optional cgroup resource limits instead of a full container image.
+type NsjailSandbox struct{}
+
+// Build assembles an `nsjail ...` invocation from cfg: mounts become
+// --bindmount[_ro] flags, network is disabled unless cfg.Network is set,
+// and cpu/memory map to nsjail's cgroup limit flags. env is passed
+// through with --env so the TRIGGERED_* vars and the tool's own Envvars
+// reach the jailed process rather than the host shell.
+func (NsjailSandbox) Build(ctx context.Context, cfg Config, env []string, workdir, shellCmd string) *exec.Cmd {
+	args := []string{"--quiet", "--disable_clone_newnet=" + boolFlag(cfg.Network)}
+	if cfg.ReadOnlyRoot {
+		args = append(args, "--chroot", "/", "--rlimit_as=hard")
+	}
+	for _, mount := range cfg.Mounts {
+		parts := strings.SplitN(mount, ":", 3)
+		flag := "--bindmount"
+		if len(parts) == 3 && parts[2] == "ro" {
+			flag = "--bindmount_ro"
+		}
+		args = append(args, flag, parts[0]+":"+parts[1])
+	}
+	if cfg.CPUs != "" {
+		args = append(args, "--cgroup_cpu_ms_per_sec", cfg.CPUs)
+	}
+	if cfg.Memory != "" {
+		args = append(args, "--cgroup_mem_max", cfg.Memory)
+	}
+	for _, kv := range env {
+		args = append(args, "--env", kv)
+	}
+	args = append(args, "--cwd", workdir, "--", "/bin/bash", "-c", shellCmd)
+
+	return exec.CommandContext(ctx, "nsjail", args...)
+}
+
+// boolFlag renders network=true as "0" (don't disable networking) and
+// network=false as "1" (disable it), matching nsjail's
+// --disable_clone_newnet polarity.
+func boolFlag(network bool) string {
+	if network {
+		return "0"
+	}
+	return "1"
+}
@@ -0,0 +1,107 @@
+// Package execution runs bashbot tool commands through a configurable
+// sandbox backend (docker, podman or nsjail), so a misbehaving or
+// malicious tool can't read the host's filesystem or environment, exhaust
+// its memory, or reach the network it shouldn't. The default "host"
+// backend runs the command directly on the bashbot host with no
+// isolation.
+package execution
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"syscall"
+	"time"
+)
+
+// Config is a tool's sandbox configuration.
+type Config struct {
+	Backend      string   `yaml:"backend"` // "" or "host" (no sandboxing), "docker", "podman", "nsjail"
+	Image        string   `yaml:"image"`
+	Mounts       []string `yaml:"mounts"` // "host_path:container_path[:ro]"
+	CPUs         string   `yaml:"cpus"`
+	Memory       string   `yaml:"memory"`
+	Network      bool     `yaml:"network"`
+	DropCaps     []string `yaml:"drop_capabilities"`
+	ReadOnlyRoot bool     `yaml:"read_only_root"`
+}
+
+// Sandbox builds the *exec.Cmd that runs a tool's resolved shell command
+// under a particular isolation backend.
+type Sandbox interface {
+	// Build returns the command that runs shellCmd inside the sandbox,
+	// with env exported into it and workdir as its working directory.
+	Build(ctx context.Context, cfg Config, env []string, workdir, shellCmd string) *exec.Cmd
+}
+
+// New returns the Sandbox configured by cfg.Backend. An empty or "host"
+// backend runs the command directly on the bashbot host, preserving the
+// pre-sandboxing behavior.
+func New(backend string) (Sandbox, error) {
+	switch backend {
+	case "", "host":
+		return HostSandbox{}, nil
+	case "docker":
+		return ContainerSandbox{Bin: "docker"}, nil
+	case "podman":
+		return ContainerSandbox{Bin: "podman"}, nil
+	case "nsjail":
+		return NsjailSandbox{}, nil
+	default:
+		return nil, fmt.Errorf("unknown sandbox backend: %s", backend)
+	}
+}
+
+// defaultOutputLimit bounds how much of each of a sandboxed command's
+// stdout and stderr bashbot will hold onto, regardless of Tool's own
+// fileThreshold, so a runaway container can't OOM the bashbot process.
+const defaultOutputLimit = 10 * 1024 * 1024 // 10MiB
+
+// cancelGracePeriod is how long a cancelled command is given to exit
+// after SIGTERM before it's SIGKILLed.
+const cancelGracePeriod = 5 * time.Second
+
+// RunCapped runs cmd and returns its stdout and stderr separately, each
+// discarding anything past limit bytes (or defaultOutputLimit if
+// limit <= 0) instead of buffering it all in memory the way
+// CombinedOutput does. Callers that want the two streams' relative
+// ordering don't get it; callers that want to tell a clean run from one
+// that wrote to stderr do.
+//
+// If cmd's context is cancelled (a tool timeout, or an operator issuing
+// "cancel <job-id>"), the process is sent SIGTERM and given
+// cancelGracePeriod to exit before Go's exec package escalates to
+// SIGKILL.
+func RunCapped(cmd *exec.Cmd, limit int) (stdout string, stderr string, err error) {
+	if limit <= 0 {
+		limit = defaultOutputLimit
+	}
+	stdoutW := &limitedWriter{limit: limit}
+	stderrW := &limitedWriter{limit: limit}
+	cmd.Stdout = stdoutW
+	cmd.Stderr = stderrW
+	cmd.Cancel = func() error {
+		return cmd.Process.Signal(syscall.SIGTERM)
+	}
+	cmd.WaitDelay = cancelGracePeriod
+	err = cmd.Run()
+	return stdoutW.buf.String(), stderrW.buf.String(), err
+}
+
+// limitedWriter caps the number of bytes it retains; writes past limit
+// are acknowledged but discarded.
+type limitedWriter struct {
+	buf   bytes.Buffer
+	limit int
+}
+
+func (w *limitedWriter) Write(p []byte) (int, error) {
+	if room := w.limit - w.buf.Len(); room > 0 {
+		if room > len(p) {
+			room = len(p)
+		}
+		w.buf.Write(p[:room])
+	}
+	return len(p), nil
+}
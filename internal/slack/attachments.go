@@ -0,0 +1,91 @@
+package slack
+
+import (
+	"regexp"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/slack-go/slack"
+)
+
+// Attachment colors used for the colored bar down the side of a slack
+// attachment, keyed by tool status / stream.
+const (
+	attachmentColorSuccess = "#2eb886"
+	attachmentColorWarn    = "#daa038"
+	attachmentColorError   = "#d00000"
+)
+
+// fieldLine matches a "key: value" line in tool output, used to build
+// AttachmentFields out of plain text.
+var fieldLine = regexp.MustCompile(`^([^:\n]{1,40}):\s*(.+)$`)
+
+// buildAttachment turns a tool's raw output into a slack.Attachment with a
+// colored bar (yellow when tool.Status is "warn"; otherwise red when
+// tool.Status is "error" or isError is set, and green when it isn't) and
+// one AttachmentField per "key: value" line found in the output.
+func buildAttachment(tool Tool, output string, isError bool) slack.Attachment {
+	color := attachmentColorSuccess
+	switch {
+	case tool.Status == "warn":
+		color = attachmentColorWarn
+	case tool.Status == "error" || isError:
+		color = attachmentColorError
+	case tool.Status == "success":
+		color = attachmentColorSuccess
+	}
+
+	var fields []slack.AttachmentField
+	var text []string
+	for _, line := range strings.Split(strings.TrimRight(output, "\n"), "\n") {
+		if match := fieldLine.FindStringSubmatch(line); match != nil {
+			fields = append(fields, slack.AttachmentField{
+				Title: match[1],
+				Value: match[2],
+				Short: true,
+			})
+			continue
+		}
+		text = append(text, line)
+	}
+
+	return slack.Attachment{
+		Color:      color,
+		Title:      tool.Name,
+		Text:       strings.Join(text, "\n"),
+		Fields:     fields,
+		MarkdownIn: []string{"text", "fields"},
+	}
+}
+
+// SendAttachmentsToChannel sends one or more slack.Attachments to a slack
+// channel, the Block Kit counterpart of SendMessageToChannel.
+func (c *Client) SendAttachmentsToChannel(channel string, attachments ...slack.Attachment) {
+	channelID, _, err := c.slackClient.PostMessage(
+		channel,
+		slack.MsgOptionAttachments(attachments...),
+		slack.MsgOptionUsername(c.cfg.Admins[0].AppName),
+	)
+	if err != nil {
+		log.Errorf("failed to send attachment to slack channel: %s", err.Error())
+		return
+	}
+	log.Infof("Sent slack attachment[Channel:%s]", channelID)
+}
+
+// SendAttachmentsToUser sends one or more slack.Attachments to a slack
+// user as an ephemeral message, the Block Kit counterpart of
+// SendMessageToUser.
+func (c *Client) SendAttachmentsToUser(channel, user string, attachments ...slack.Attachment) {
+	_, err := c.slackClient.PostEphemeral(
+		channel,
+		user,
+		slack.MsgOptionAttachments(attachments...),
+		slack.MsgOptionUsername(c.cfg.Admins[0].AppName),
+	)
+	if err != nil {
+		log.Error(err)
+		return
+	}
+	log.Info("Sent ephemeral slack attachment[Channel:" + channel + "]")
+}
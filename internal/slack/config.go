@@ -0,0 +1,83 @@
+package slack
+
+import (
+	"github.com/wisdommatt/bashbot/internal/audit"
+	"github.com/wisdommatt/bashbot/internal/execution"
+)
+
+// Config is the root of the bashbot YAML configuration file.
+type Config struct {
+	Admins       []Admin      `yaml:"admins"`
+	Messages     []Message    `yaml:"messages"`
+	Dependencies []Dependency `yaml:"dependencies"`
+	Tools        []Tool       `yaml:"tools"`
+	PluginsDir   string       `yaml:"plugins_dir"`
+	Audit        audit.Config `yaml:"audit"`
+}
+
+// Admin holds the top-level settings for the running bashbot instance,
+// such as the channel it logs to and the word used to trigger commands.
+type Admin struct {
+	AppName          string `yaml:"app_name"`
+	Trigger          string `yaml:"trigger"`
+	PrivateChannelId string `yaml:"private_channel_id"`
+	LogChannelId     string `yaml:"log_channel_id"`
+}
+
+// Message is a configurable, named response bashbot can send back to a
+// channel, e.g. "command_not_found" or "unauthorized".
+type Message struct {
+	Name   string `yaml:"name"`
+	Active bool   `yaml:"active"`
+	Text   string `yaml:"text"`
+}
+
+// Dependency describes a vendored dependency and the shell command used
+// to install it.
+type Dependency struct {
+	Name    string   `yaml:"name"`
+	Install []string `yaml:"install"`
+}
+
+// Tool is a single YAML-configured command bashbot can run.
+type Tool struct {
+	Name          string           `yaml:"name"`
+	Description   string           `yaml:"description"`
+	Help          string           `yaml:"help"`
+	Trigger       string           `yaml:"trigger"`
+	Location      string           `yaml:"location"`
+	Command       []string         `yaml:"command"`
+	Log           bool             `yaml:"log"`
+	Ephemeral     bool             `yaml:"ephemeral"`
+	Response      string           `yaml:"response"`
+	Status        string           `yaml:"status"`
+	Permissions   []string         `yaml:"permissions"`
+	Envvars       []string         `yaml:"envvars"`
+	Dependencies  []string         `yaml:"dependencies"`
+	Parameters    []Parameter      `yaml:"parameters"`
+	Timeout       string           `yaml:"timeout"`        // e.g. "30s", "5m"; empty means no timeout
+	MaxConcurrent int              `yaml:"max_concurrent"` // 0 means unlimited
+	RateLimit     int              `yaml:"rate_limit"`     // max invocations per RatePer, per user and separately per channel; 0 means unlimited
+	RatePer       string           `yaml:"rate_per"`       // e.g. "1m"; defaults to "1m" when RateLimit is set
+	Sandbox       execution.Config `yaml:"sandbox"`
+}
+
+// Parameter describes one positional argument a Tool accepts.
+type Parameter struct {
+	Name        string   `yaml:"name"`
+	Description string   `yaml:"description"`
+	Allowed     []string `yaml:"allowed"`
+	Match       string   `yaml:"match"`
+	Source      []string `yaml:"source"`
+}
+
+// GetTool returns the Tool configured under the given trigger word, or a
+// zero-value Tool (empty Trigger) if no tool matches.
+func (c *Config) GetTool(trigger string) Tool {
+	for _, tool := range c.Tools {
+		if tool.Trigger == trigger {
+			return tool
+		}
+	}
+	return Tool{}
+}
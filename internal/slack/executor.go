@@ -0,0 +1,187 @@
+package slack
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Job is one in-flight invocation of a shell tool, tracked so it can be
+// listed via the "jobs" command and stopped via "cancel <job-id>".
+type Job struct {
+	ID        string
+	Tool      string
+	Channel   string
+	User      string
+	StartedAt time.Time
+	cancel    context.CancelFunc
+}
+
+// Executor runs shell tools off the socketmode event loop, so a slow tool
+// no longer blocks the processing of other slack events. It enforces each
+// tool's configured timeout, max_concurrent, and per-user/per-channel
+// rate limit.
+type Executor struct {
+	mu          sync.Mutex
+	nextID      int
+	jobs        map[string]*Job
+	sems        map[string]chan struct{}
+	invocations map[string][]time.Time
+}
+
+// NewExecutor returns a ready-to-use Executor.
+func NewExecutor() *Executor {
+	return &Executor{
+		jobs:        make(map[string]*Job),
+		sems:        make(map[string]chan struct{}),
+		invocations: make(map[string][]time.Time),
+	}
+}
+
+// semaphoreFor returns the concurrency semaphore for tool, creating it
+// the first time the tool is seen. A MaxConcurrent of 0 means unlimited,
+// represented by a nil channel (acquire/release become no-ops).
+func (e *Executor) semaphoreFor(tool Tool) chan struct{} {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if tool.MaxConcurrent <= 0 {
+		return nil
+	}
+	sem, ok := e.sems[tool.Trigger]
+	if !ok {
+		sem = make(chan struct{}, tool.MaxConcurrent)
+		e.sems[tool.Trigger] = sem
+	}
+	return sem
+}
+
+// countInWindow drops key's recorded invocations older than now-per and
+// returns how many remain, i.e. how many invocations have happened
+// within the trailing window.
+func (e *Executor) countInWindow(key string, now time.Time, per time.Duration) int {
+	cutoff := now.Add(-per)
+	kept := e.invocations[key][:0]
+	for _, t := range e.invocations[key] {
+		if t.After(cutoff) {
+			kept = append(kept, t)
+		}
+	}
+	e.invocations[key] = kept
+	return len(kept)
+}
+
+// allow reports whether tool may run again for channel/user without
+// exceeding RateLimit invocations per RatePer, checked independently for
+// the user and the channel, recording this invocation against both if
+// it's allowed. A RateLimit of 0 means unlimited.
+func (e *Executor) allow(tool Tool, channel, user string) bool {
+	if tool.RateLimit <= 0 {
+		return true
+	}
+	per := 1 * time.Minute
+	if tool.RatePer != "" {
+		if d, err := time.ParseDuration(tool.RatePer); err == nil {
+			per = d
+		}
+	}
+	userKey := tool.Trigger + ":user:" + user
+	channelKey := tool.Trigger + ":channel:" + channel
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	now := time.Now()
+	if e.countInWindow(userKey, now, per) >= tool.RateLimit {
+		return false
+	}
+	if e.countInWindow(channelKey, now, per) >= tool.RateLimit {
+		return false
+	}
+	e.invocations[userKey] = append(e.invocations[userKey], now)
+	e.invocations[channelKey] = append(e.invocations[channelKey], now)
+	return true
+}
+
+// Submit runs fn in its own goroutine, subject to tool's timeout,
+// max_concurrent and rate_limit settings, and registers it as a Job so it
+// shows up in "jobs" and can be stopped with "cancel <job-id>". fn is
+// handed the context it should run under and must respect cancellation.
+//
+// Callers must only Submit invocations that have already passed channel
+// authorization and aren't a bare "help" lookup, so rate-limit budget and
+// job slots aren't spent on requests that never reach the sandbox.
+func (e *Executor) Submit(tool Tool, channel, user string, fn func(ctx context.Context)) (string, error) {
+	if !e.allow(tool, channel, user) {
+		return "", fmt.Errorf("rate limit exceeded for %q", tool.Trigger)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	if tool.Timeout != "" {
+		if d, err := time.ParseDuration(tool.Timeout); err == nil {
+			var timeoutCancel context.CancelFunc
+			ctx, timeoutCancel = context.WithTimeout(ctx, d)
+			parentCancel := cancel
+			cancel = func() {
+				timeoutCancel()
+				parentCancel()
+			}
+		} else {
+			log.WithError(err).Errorf("invalid timeout %q for tool %q", tool.Timeout, tool.Trigger)
+		}
+	}
+
+	e.mu.Lock()
+	e.nextID++
+	id := fmt.Sprintf("%s-%d", tool.Trigger, e.nextID)
+	job := &Job{ID: id, Tool: tool.Trigger, Channel: channel, User: user, StartedAt: time.Now(), cancel: cancel}
+	e.jobs[id] = job
+	e.mu.Unlock()
+
+	sem := e.semaphoreFor(tool)
+	go func() {
+		defer func() {
+			e.mu.Lock()
+			delete(e.jobs, id)
+			e.mu.Unlock()
+			cancel()
+		}()
+		if sem != nil {
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				return
+			}
+		}
+		fn(ctx)
+	}()
+	return id, nil
+}
+
+// Cancel stops the job with the given id, if it's still running. This
+// cancels the context the job's command runs under; execution.RunCapped
+// turns that into a SIGTERM followed by a SIGKILL if the process doesn't
+// exit in time.
+func (e *Executor) Cancel(id string) bool {
+	e.mu.Lock()
+	job, ok := e.jobs[id]
+	e.mu.Unlock()
+	if !ok {
+		return false
+	}
+	job.cancel()
+	return true
+}
+
+// Jobs returns a snapshot of every job currently in flight.
+func (e *Executor) Jobs() []Job {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	jobs := make([]Job, 0, len(e.jobs))
+	for _, job := range e.jobs {
+		jobs = append(jobs, *job)
+	}
+	return jobs
+}
@@ -0,0 +1,76 @@
+package slack
+
+import (
+	"context"
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// CommandHandler is the interface any bashbot command must implement,
+// whether it's one of the YAML-configured shell tools or a native Go
+// command loaded from a plugin.
+type CommandHandler interface {
+	Name() string
+	Help() string
+	Permissions() []string
+	Execute(ctx context.Context, args []string, invocation Invocation) (Result, error)
+}
+
+// Invocation carries the slack context a command was triggered from.
+type Invocation struct {
+	Channel   string
+	User      string
+	Timestamp string
+}
+
+// Result is what a CommandHandler returns to be relayed back to the
+// channel or user that triggered it.
+type Result struct {
+	Output    string
+	Ephemeral bool
+}
+
+// RegisterHandler registers a native CommandHandler under its own Name,
+// making it reachable the same way a YAML-configured tool is. A handler
+// registered here takes priority over a shell tool sharing the same
+// trigger word.
+func (c *Client) RegisterHandler(handler CommandHandler) {
+	if c.handlers == nil {
+		c.handlers = make(map[string]CommandHandler)
+	}
+	c.handlers[handler.Name()] = handler
+	log.Infof("Registered command handler: %s", handler.Name())
+}
+
+// ShellTool adapts a YAML-configured Tool into a CommandHandler backed by
+// the existing processValidCommand shell-execution path, so shell tools
+// and native Go handlers can be dispatched the same way.
+type ShellTool struct {
+	client *Client
+	tool   Tool
+}
+
+// Name returns the trigger word used to invoke the shell tool.
+func (s *ShellTool) Name() string {
+	return s.tool.Trigger
+}
+
+// Help returns the configured help text for the shell tool.
+func (s *ShellTool) Help() string {
+	return s.tool.Help
+}
+
+// Permissions returns the channels the shell tool is allowed to run in.
+func (s *ShellTool) Permissions() []string {
+	return s.tool.Permissions
+}
+
+// Execute runs the shell tool through processValidCommand.
+func (s *ShellTool) Execute(ctx context.Context, args []string, invocation Invocation) (Result, error) {
+	ok := s.client.processValidCommand(ctx, args, s.tool, invocation.Channel, invocation.User, invocation.Timestamp)
+	if !ok {
+		return Result{}, fmt.Errorf("shell tool %q did not complete successfully", s.tool.Trigger)
+	}
+	return Result{}, nil
+}
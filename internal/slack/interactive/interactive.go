@@ -0,0 +1,300 @@
+// Package interactive handles slack's HTTP-based interactivity: the
+// `/bashbot` slash command and the `view_submission` payloads slack posts
+// when a modal opened from that command is submitted.
+//
+// A `/bashbot` slash command that supplies fewer args than the tool has
+// Parameters opens a views.open modal populated from those Parameters
+// instead of running the tool. A tool triggered from a plain slack
+// message is not prompted this way: it still gets the
+// "invalid_parameter" reply when a parameter is missing.
+package interactive
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/slack-go/slack"
+)
+
+// Tool is the subset of a bashbot tool's configuration the interactive
+// handlers need, decoupled from the slack package's Tool/Parameter types
+// so this package can be imported without an import cycle.
+type Tool struct {
+	Trigger    string
+	Name       string
+	Parameters []Parameter
+}
+
+// Parameter describes one positional argument a Tool accepts, the same
+// way slack.Parameter does.
+type Parameter struct {
+	Name        string
+	Description string
+	Allowed     []string
+	Match       string
+	Source      []string
+}
+
+// Backend is implemented by slack.Client to give the interactive
+// handlers access to tool configuration and command execution.
+type Backend interface {
+	// LookupTool returns the tool configured under trigger, or ok=false.
+	LookupTool(trigger string) (Tool, bool)
+	// OpenParameterModal opens a views.open modal for tool's parameters.
+	OpenParameterModal(triggerID, channel, user string, tool Tool) error
+	// RunTool executes tool with the given positional args, the same way
+	// typing them after the bashbot trigger word would.
+	RunTool(tool Tool, channel, user string, args []string) error
+	// DeriveOptions runs the named parameter's Source command and returns
+	// its allowed values, for populating an external_select's options.
+	DeriveOptions(tool Tool, paramName string) ([]string, error)
+}
+
+// Handler serves the HTTP endpoints slack calls for slash commands and
+// interactivity payloads.
+type Handler struct {
+	signingSecret string
+	backend       Backend
+}
+
+// NewHandler returns a Handler that verifies requests against
+// signingSecret (the app's "Signing Secret" from the slack app config)
+// and dispatches tool lookups/execution to backend.
+func NewHandler(signingSecret string, backend Backend) *Handler {
+	return &Handler{signingSecret: signingSecret, backend: backend}
+}
+
+// verify checks body against slack's request signature, consuming r's
+// raw headers (X-Slack-Signature / X-Slack-Request-Timestamp).
+func (h *Handler) verify(r *http.Request, body []byte) error {
+	verifier, err := slack.NewSecretsVerifier(r.Header, h.signingSecret)
+	if err != nil {
+		return err
+	}
+	if _, err := verifier.Write(body); err != nil {
+		return err
+	}
+	return verifier.Ensure()
+}
+
+// HandleSlashCommand handles a `/bashbot <tool> [args...]` slash command.
+// If the tool has more Parameters than args supplied, it opens a modal
+// asking for all of the tool's Parameters instead of running the tool;
+// the args already supplied are not carried over into the modal.
+func (h *Handler) HandleSlashCommand(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	if err := h.verify(r, body); err != nil {
+		http.Error(w, "invalid signature", http.StatusUnauthorized)
+		return
+	}
+
+	cmd, err := slack.SlashCommandParse(r)
+	if err != nil {
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+
+	words := strings.Fields(cmd.Text)
+	if len(words) == 0 {
+		http.Error(w, "usage: "+cmd.Command+" <tool> [args...]", http.StatusOK)
+		return
+	}
+
+	tool, ok := h.backend.LookupTool(words[0])
+	if !ok {
+		w.Write([]byte("unknown command: " + words[0]))
+		return
+	}
+
+	args := words[1:]
+	if len(args) < len(tool.Parameters) {
+		privateMetadata := strings.Join([]string{tool.Trigger, cmd.ChannelID, cmd.UserID}, "|")
+		if err := h.backend.OpenParameterModal(cmd.TriggerID, cmd.ChannelID, cmd.UserID, tool); err != nil {
+			log.WithError(err).Errorf("failed to open parameter modal for %q", tool.Trigger)
+			http.Error(w, "failed to open parameter prompt", http.StatusInternalServerError)
+			return
+		}
+		log.Debugf("opened parameter modal for %q (metadata: %s)", tool.Trigger, privateMetadata)
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	if err := h.backend.RunTool(tool, cmd.ChannelID, cmd.UserID, args); err != nil {
+		log.WithError(err).Errorf("failed to run %q", tool.Trigger)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// HandleInteraction handles the view_submission payload slack posts when
+// the parameter modal opened from HandleSlashCommand is submitted.
+func (h *Handler) HandleInteraction(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	if err := h.verify(r, body); err != nil {
+		http.Error(w, "invalid signature", http.StatusUnauthorized)
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+
+	var callback slack.InteractionCallback
+	if err := json.Unmarshal([]byte(r.FormValue("payload")), &callback); err != nil {
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+	if callback.Type != slack.InteractionTypeViewSubmission {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	parts := strings.SplitN(callback.View.PrivateMetadata, "|", 3)
+	if len(parts) != 3 {
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+	trigger, channel, user := parts[0], parts[1], parts[2]
+
+	tool, ok := h.backend.LookupTool(trigger)
+	if !ok {
+		http.Error(w, "unknown command: "+trigger, http.StatusBadRequest)
+		return
+	}
+
+	args := make([]string, len(tool.Parameters))
+	for i, param := range tool.Parameters {
+		state, ok := callback.View.State.Values["param_"+param.Name]
+		if !ok {
+			continue
+		}
+		action, ok := state["value"]
+		if !ok {
+			continue
+		}
+		if action.SelectedOption.Value != "" {
+			args[i] = action.SelectedOption.Value
+			continue
+		}
+		args[i] = action.Value
+	}
+
+	if fieldErrors := validateParams(tool, args); len(fieldErrors) > 0 {
+		writeJSON(w, map[string]interface{}{
+			"response_action": "errors",
+			"errors":          fieldErrors,
+		})
+		return
+	}
+
+	if err := h.backend.RunTool(tool, channel, user, args); err != nil {
+		log.WithError(err).Errorf("failed to run %q", tool.Trigger)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// validateParams checks args against each parameter's Match regex (the
+// same regex processValidCommand enforces on a plain-text invocation),
+// returning one response_action error per failing block so slack shows
+// it inline on the still-open modal instead of silently submitting.
+func validateParams(tool Tool, args []string) map[string]string {
+	fieldErrors := make(map[string]string)
+	for i, param := range tool.Parameters {
+		if param.Match == "" {
+			continue
+		}
+		re, err := regexp.Compile(param.Match)
+		if err != nil {
+			log.WithError(err).Errorf("invalid match regex for parameter %q", param.Name)
+			continue
+		}
+		if !re.MatchString(args[i]) {
+			fieldErrors["param_"+param.Name] = fmt.Sprintf("must match %s", param.Match)
+		}
+	}
+	return fieldErrors
+}
+
+// HandleBlockSuggestion handles the block_suggestion payload slack posts
+// when a user interacts with an external_select, returning options
+// derived from the parameter's configured Source command.
+func (h *Handler) HandleBlockSuggestion(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	if err := h.verify(r, body); err != nil {
+		http.Error(w, "invalid signature", http.StatusUnauthorized)
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+
+	var callback slack.InteractionCallback
+	if err := json.Unmarshal([]byte(r.FormValue("payload")), &callback); err != nil {
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+
+	parts := strings.SplitN(callback.View.PrivateMetadata, "|", 3)
+	if len(parts) != 3 {
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+	tool, ok := h.backend.LookupTool(parts[0])
+	if !ok {
+		http.Error(w, "unknown command: "+parts[0], http.StatusBadRequest)
+		return
+	}
+
+	paramName := strings.TrimPrefix(callback.BlockID, "param_")
+	values, err := h.backend.DeriveOptions(tool, paramName)
+	if err != nil {
+		log.WithError(err).Errorf("failed to derive options for %q.%s", tool.Trigger, paramName)
+		writeJSON(w, map[string]interface{}{"options": []interface{}{}})
+		return
+	}
+
+	options := make([]map[string]interface{}, 0, len(values))
+	for _, value := range values {
+		if value == "" {
+			continue
+		}
+		options = append(options, map[string]interface{}{
+			"text":  map[string]interface{}{"type": "plain_text", "text": value},
+			"value": value,
+		})
+	}
+	writeJSON(w, map[string]interface{}{"options": options})
+}
+
+// writeJSON encodes v as the JSON body of a slack interactivity response.
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.WithError(err).Error("failed to write interactivity response")
+	}
+}
@@ -0,0 +1,46 @@
+package interactive
+
+import "github.com/slack-go/slack"
+
+// BuildParameterModal builds the views.open request used to collect a
+// tool's missing parameters: Allowed becomes a static_select, Match
+// becomes a plain_text_input (validated server-side on submission), and a
+// parameter with neither becomes an external_select fed by the tool's
+// derived Source command.
+func BuildParameterModal(tool Tool, privateMetadata string) slack.ModalViewRequest {
+	var blocks []slack.Block
+	for _, param := range tool.Parameters {
+		label := slack.NewTextBlockObject(slack.PlainTextType, param.Name, false, false)
+		blockID := "param_" + param.Name
+
+		var element slack.BlockElement
+		switch {
+		case param.Match != "":
+			element = slack.NewPlainTextInputBlockElement(nil, "value")
+		case len(param.Allowed) > 0:
+			options := make([]*slack.OptionBlockObject, len(param.Allowed))
+			for i, allowed := range param.Allowed {
+				options[i] = slack.NewOptionBlockObject(
+					allowed,
+					slack.NewTextBlockObject(slack.PlainTextType, allowed, false, false),
+					nil,
+				)
+			}
+			element = slack.NewOptionsSelectBlockElement(slack.OptTypeStatic, nil, "value", options...)
+		default:
+			element = slack.NewOptionsSelectBlockElement(slack.OptTypeExternal, nil, "value")
+		}
+
+		blocks = append(blocks, slack.NewInputBlock(blockID, label, nil, element))
+	}
+
+	return slack.ModalViewRequest{
+		Type:            slack.VTModal,
+		CallbackID:      "bashbot_tool_params",
+		Title:           slack.NewTextBlockObject(slack.PlainTextType, tool.Name, false, false),
+		Submit:          slack.NewTextBlockObject(slack.PlainTextType, "Run", false, false),
+		Close:           slack.NewTextBlockObject(slack.PlainTextType, "Cancel", false, false),
+		Blocks:          slack.Blocks{BlockSet: blocks},
+		PrivateMetadata: privateMetadata,
+	}
+}
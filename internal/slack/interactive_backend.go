@@ -0,0 +1,100 @@
+package slack
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/wisdommatt/bashbot/internal/slack/interactive"
+)
+
+// InteractiveHandler returns the HTTP handler for the `/bashbot` slash
+// command and the interactivity payloads slack posts when a parameter
+// modal opened from it is submitted. Mount its HandleSlashCommand and
+// HandleInteraction methods at whatever request URLs the slack app is
+// configured with.
+func (c *Client) InteractiveHandler(signingSecret string) *interactive.Handler {
+	return interactive.NewHandler(signingSecret, c)
+}
+
+// LookupTool implements interactive.Backend.
+func (c *Client) LookupTool(trigger string) (interactive.Tool, bool) {
+	tool := c.cfg.GetTool(trigger)
+	if tool.Trigger != trigger {
+		return interactive.Tool{}, false
+	}
+	return toInteractiveTool(tool), true
+}
+
+// OpenParameterModal implements interactive.Backend.
+func (c *Client) OpenParameterModal(triggerID, channel, user string, tool interactive.Tool) error {
+	privateMetadata := strings.Join([]string{tool.Trigger, channel, user}, "|")
+	_, err := c.slackClient.OpenView(triggerID, interactive.BuildParameterModal(tool, privateMetadata))
+	return err
+}
+
+// RunTool implements interactive.Backend, executing tool the same way it
+// would run if triggered directly in a slack message.
+func (c *Client) RunTool(tool interactive.Tool, channel, user string, args []string) error {
+	cfgTool := c.cfg.GetTool(tool.Trigger)
+	if cfgTool.Trigger != tool.Trigger {
+		return fmt.Errorf("unknown command: %s", tool.Trigger)
+	}
+	shellTool := &ShellTool{client: c, tool: cfgTool}
+	invocation := Invocation{Channel: channel, User: user, Timestamp: strconv.FormatInt(time.Now().Unix(), 10)}
+	// As in processCommand, help lookups and unauthorized channels are
+	// answered by processValidCommand directly, without going through the
+	// executor, so neither spends rate-limit budget or a job slot.
+	if isHelpRequest(args) || !c.isAuthorizedChannel(channel, cfgTool.Permissions) {
+		_, err := shellTool.Execute(context.Background(), args, invocation)
+		return err
+	}
+	_, err := c.executor.Submit(cfgTool, channel, user, func(ctx context.Context) {
+		if _, err := shellTool.Execute(ctx, args, invocation); err != nil {
+			log.WithError(err).Errorf("command %q failed", tool.Trigger)
+		}
+	})
+	return err
+}
+
+// DeriveOptions implements interactive.Backend, running paramName's
+// Source command the same way processValidCommand derives Allowed
+// values for a plain-text invocation.
+func (c *Client) DeriveOptions(tool interactive.Tool, paramName string) ([]string, error) {
+	cfgTool := c.cfg.GetTool(tool.Trigger)
+	if cfgTool.Trigger != tool.Trigger {
+		return nil, fmt.Errorf("unknown command: %s", tool.Trigger)
+	}
+	for _, param := range cfgTool.Parameters {
+		if param.Name != paramName {
+			continue
+		}
+		allowed := append([]string{}, param.Allowed...)
+		if len(param.Source) > 0 {
+			derived := strings.Split(c.runShellCommands([]string{"bash", "-c", "cd " + cfgTool.Location + " && " + strings.Join(param.Source, " ")}), "\n")
+			allowed = append(allowed, derived...)
+		}
+		return allowed, nil
+	}
+	return nil, fmt.Errorf("unknown parameter %q on %q", paramName, tool.Trigger)
+}
+
+// toInteractiveTool converts a YAML-configured Tool into the slimmer
+// interactive.Tool the interactive package works with.
+func toInteractiveTool(tool Tool) interactive.Tool {
+	params := make([]interactive.Parameter, len(tool.Parameters))
+	for i, p := range tool.Parameters {
+		params[i] = interactive.Parameter{
+			Name:        p.Name,
+			Description: p.Description,
+			Allowed:     p.Allowed,
+			Match:       p.Match,
+			Source:      p.Source,
+		}
+	}
+	return interactive.Tool{Trigger: tool.Trigger, Name: tool.Name, Parameters: params}
+}
@@ -0,0 +1,49 @@
+package slack
+
+import (
+	"os"
+	"path/filepath"
+	"plugin"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// LoadPluginsFromDirectory loads every Go plugin (a ".so" file built with
+// `go build -buildmode=plugin`) found in dir and registers the
+// CommandHandler each one exports as "Handler".
+//
+// This lets operators extend bashbot with native Go commands (stats, AoC
+// leaderboards, custom integrations, etc.) without having to shell out to
+// a YAML-driven tool. An empty dir is a no-op.
+func (c *Client) LoadPluginsFromDirectory(dir string) error {
+	if dir == "" {
+		return nil
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".so" {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		p, err := plugin.Open(path)
+		if err != nil {
+			log.WithError(err).Errorf("failed to open plugin: %s", path)
+			continue
+		}
+		sym, err := p.Lookup("Handler")
+		if err != nil {
+			log.WithError(err).Errorf("plugin %s does not export a Handler symbol", path)
+			continue
+		}
+		handler, ok := sym.(CommandHandler)
+		if !ok {
+			log.Errorf("plugin %s Handler does not implement slack.CommandHandler", path)
+			continue
+		}
+		c.RegisterHandler(handler)
+	}
+	return nil
+}
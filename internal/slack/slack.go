@@ -1,6 +1,8 @@
 package slack
 
 import (
+	"context"
+	"crypto/sha256"
 	"fmt"
 	"gopkg.in/yaml.v2"
 	"os"
@@ -8,17 +10,24 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 	"github.com/slack-go/slack"
 	"github.com/slack-go/slack/slackevents"
 	"github.com/slack-go/slack/socketmode"
+
+	"github.com/wisdommatt/bashbot/internal/audit"
+	"github.com/wisdommatt/bashbot/internal/execution"
 )
 
 type Client struct {
 	slackClient  *slack.Client
 	socketClient *socketmode.Client
 	cfg          *Config
+	handlers     map[string]CommandHandler
+	auditLogger  audit.Logger
+	executor     *Executor
 }
 
 // NewSlackClient creates a new slack client.
@@ -44,11 +53,25 @@ func NewSlackClient(configFile, botToken, appToken string) *Client {
 	}
 	api := slack.New(botToken, slack.OptionAppLevelToken(appToken))
 	client := socketmode.New(api)
-	return &Client{
+	c := &Client{
 		cfg:          cfg,
 		socketClient: client,
 		slackClient:  api,
+		handlers:     make(map[string]CommandHandler),
+		executor:     NewExecutor(),
+	}
+	if err := c.LoadPluginsFromDirectory(cfg.PluginsDir); err != nil {
+		log.WithError(err).Error("Problem loading plugins directory")
 	}
+	if cfg.Audit.ChannelID == "" {
+		cfg.Audit.ChannelID = cfg.Admins[0].LogChannelId
+	}
+	auditLogger, err := audit.New(cfg.Audit, c)
+	if err != nil {
+		log.WithError(err).Fatal("Problem configuring audit logger")
+	}
+	c.auditLogger = auditLogger
+	return c
 }
 
 // loadConfigFile is a helper function for loading bashbot yaml
@@ -140,8 +163,21 @@ func (c *Client) InstallVendorDependencies() {
 // The first value in the array should be the command name e.g bash, sh etc
 // while the other values will be treated as arguments.
 func (c *Client) runShellCommands(cmdArgs []string) string {
-	cmdOut, err := exec.Command(cmdArgs[0], cmdArgs[1:]...).CombinedOutput()
+	return c.runShellCommandsContext(context.Background(), cmdArgs)
+}
+
+// runShellCommandsContext is runShellCommands with a context.Context, so
+// the caller can bound the command with a timeout or cancel it early
+// (e.g. via the executor's "cancel <job-id>" command).
+func (c *Client) runShellCommandsContext(ctx context.Context, cmdArgs []string) string {
+	cmdOut, err := exec.CommandContext(ctx, cmdArgs[0], cmdArgs[1:]...).CombinedOutput()
 	if err != nil {
+		switch ctx.Err() {
+		case context.DeadlineExceeded:
+			return fmt.Sprintf("error running command:\n%s\nerror: timed out", strings.Join(cmdArgs, " "))
+		case context.Canceled:
+			return fmt.Sprintf("error running command:\n%s\nerror: cancelled", strings.Join(cmdArgs, " "))
+		}
 		return fmt.Sprintf("error running command:\n%s\nerror: %s", strings.Join(cmdArgs, " "), err.Error())
 	}
 	out := string(cmdOut)
@@ -288,12 +324,90 @@ func (c *Client) processCommand(event *slackevents.MessageEvent) bool {
 		}
 	}
 
-	tool := c.cfg.GetTool(words[1])
-	switch words[1] {
-	case tool.Trigger:
+	handler, ok := c.handlers[words[1]]
+	if !ok {
+		if tool := c.cfg.GetTool(words[1]); tool.Trigger == words[1] {
+			handler = &ShellTool{client: c, tool: tool}
+			ok = true
+		}
+	}
+
+	switch {
+	case ok:
 		c.sendConfigMessageToChannel(event.Channel, "processing_command", "")
-		return c.processValidCommand(cmd, tool, event.Channel, event.User, event.TimeStamp)
-	case "exit":
+		invocation := Invocation{Channel: event.Channel, User: event.User, Timestamp: event.TimeStamp}
+		shellTool, isShellTool := handler.(*ShellTool)
+		if !isShellTool {
+			if !c.isAuthorizedChannel(event.Channel, handler.Permissions()) {
+				c.sendConfigMessageToChannel(event.Channel, "unauthorized", strings.Join(c.getChannelNames(handler.Permissions()), ", "))
+				return false
+			}
+			result, err := handler.Execute(context.Background(), cmd, invocation)
+			if err != nil {
+				log.WithError(err).Errorf("command %q failed", words[1])
+				return false
+			}
+			if result.Output != "" {
+				if result.Ephemeral {
+					c.SendMessageToUser(event.Channel, event.User, result.Output)
+				} else {
+					c.SendMessageToChannel(event.Channel, result.Output)
+				}
+			}
+			return true
+		}
+		// Gate authorization and "help" lookups before handing the job to
+		// the executor, so neither spends rate-limit budget or a job slot
+		// (processValidCommand still enforces the same check, since the
+		// executor isn't the only path into it).
+		if isHelpRequest(cmd) {
+			c.SendMessageToChannel(event.Channel, c.toolHelpText(shellTool.tool))
+			return true
+		}
+		if !c.isAuthorizedChannel(event.Channel, shellTool.tool.Permissions) {
+			c.sendConfigMessageToChannel(event.Channel, "unauthorized", strings.Join(c.getChannelNames(shellTool.tool.Permissions), ", "))
+			c.SendMessageToChannel(event.Channel, c.toolHelpText(shellTool.tool))
+			if thisUser, err := c.slackClient.GetUserInfo(event.User); err == nil {
+				c.emitAuditEvent(thisUser, shellTool.tool, event.Channel, strings.Join(cmd, " "), "", -1, 0, "unauthorized")
+			}
+			return true
+		}
+		// Shell tools run off the event loop through the executor, so a
+		// slow tool doesn't block other slack events from being handled.
+		jobID, err := c.executor.Submit(shellTool.tool, event.Channel, event.User, func(ctx context.Context) {
+			if _, err := shellTool.Execute(ctx, cmd, invocation); err != nil {
+				log.WithError(err).Errorf("command %q failed", words[1])
+			}
+		})
+		if err != nil {
+			c.SendMessageToChannel(event.Channel, err.Error())
+			return false
+		}
+		log.Infof("Submitted job %s", jobID)
+		return true
+	case words[1] == "jobs":
+		if c.cfg.Admins[0].PrivateChannelId != event.Channel {
+			c.sendConfigMessageToChannel(event.Channel, "unauthorized", "")
+			return false
+		}
+		c.SendMessageToChannel(event.Channel, c.formatJobs())
+		return true
+	case words[1] == "cancel":
+		if c.cfg.Admins[0].PrivateChannelId != event.Channel {
+			c.sendConfigMessageToChannel(event.Channel, "unauthorized", "")
+			return false
+		}
+		if len(words) < 3 {
+			c.SendMessageToChannel(event.Channel, "usage: cancel <job-id>")
+			return false
+		}
+		if c.executor.Cancel(words[2]) {
+			c.SendMessageToChannel(event.Channel, fmt.Sprintf("cancelled job %s", words[2]))
+			return true
+		}
+		c.SendMessageToChannel(event.Channel, fmt.Sprintf("no running job found with id %s", words[2]))
+		return false
+	case words[1] == "exit":
 		if len(words) == 3 {
 			switch words[2] {
 			case "0":
@@ -313,6 +427,21 @@ func (c *Client) processCommand(event *slackevents.MessageEvent) bool {
 	}
 }
 
+// formatJobs renders every job currently in flight for the "jobs" command.
+func (c *Client) formatJobs() string {
+	jobs := c.executor.Jobs()
+	if len(jobs) == 0 {
+		return "no jobs currently running"
+	}
+	var out strings.Builder
+	out.WriteString("```")
+	for _, job := range jobs {
+		fmt.Fprintf(&out, "%s  %-20s  elapsed: %s\n", job.ID, job.Tool, time.Since(job.StartedAt).Round(time.Second))
+	}
+	out.WriteString("```")
+	return out.String()
+}
+
 // validateRequiredEnvVars is a helper function for checking if required environment variables
 // are available for bashbot.
 //
@@ -344,7 +473,41 @@ func (c *Client) validateRequiredDependencies(channel string, tool Tool) error {
 	return nil
 }
 
-func (c *Client) processValidCommand(cmds []string, tool Tool, channel, user, timestamp string) bool {
+// isAuthorizedChannel reports whether channel is allowed to invoke a
+// command whose configured permissions are permissions. The admin
+// private channel is always authorized, regardless of permissions.
+func (c *Client) isAuthorizedChannel(channel string, permissions []string) bool {
+	if c.cfg.Admins[0].PrivateChannelId == channel {
+		return true
+	}
+	for j := 0; j < len(permissions); j++ {
+		log.Debugf(" ----> Param Permissions[%d]: %s", j, permissions[j])
+		if permissions[j] == channel || permissions[j] == "all" {
+			return true
+		}
+	}
+	return false
+}
+
+// isHelpRequest reports whether any word of a command invocation is the
+// literal "help", the same check processValidCommand uses to show a
+// tool's usage instead of running it.
+func isHelpRequest(cmds []string) bool {
+	for j := 0; j < len(cmds); j++ {
+		if cmds[j] == "help" {
+			return true
+		}
+	}
+	return false
+}
+
+// toolHelpText renders the same usage block processValidCommand shows
+// for "<trigger> help" or an unauthorized invocation.
+func (c *Client) toolHelpText(tool Tool) string {
+	return fmt.Sprintf("``` ====> %s [Allowed In: %s] <====\n%s\n%s```", tool.Name, strings.Join(c.getChannelNames(tool.Permissions), ", "), tool.Description, tool.Help)
+}
+
+func (c *Client) processValidCommand(ctx context.Context, cmds []string, tool Tool, channel, user, timestamp string) bool {
 	err := c.validateRequiredEnvVars(channel, tool)
 	if err != nil {
 		return false
@@ -372,35 +535,18 @@ func (c *Client) processValidCommand(cmds []string, tool Tool, channel, user, ti
 	log.Infof(" ----> Param Ephemeral:   %s", strconv.FormatBool(tool.Ephemeral))
 	log.Infof(" ----> Param Response:    %s", tool.Response)
 	validParams := make([]bool, len(tool.Parameters))
-	var tmpHelp string
-	authorized := false
-	var allowedChannels []string = c.getChannelNames(tool.Permissions)
-	if c.cfg.Admins[0].PrivateChannelId == channel {
-		authorized = true
-	} else {
-		for j := 0; j < len(tool.Permissions); j++ {
-			log.Debugf(" ----> Param Permissions[%d]: %s", j, tool.Permissions[j])
-			if tool.Permissions[j] == channel || tool.Permissions[j] == "all" {
-				authorized = true
-			}
-		}
-	}
+	authorized := c.isAuthorizedChannel(channel, tool.Permissions)
 
 	// Show help if the first parameter is "help"
-	cmdHelp := fmt.Sprintf("``` ====> %s [Allowed In: %s] <====\n%s\n%s%s```", tool.Name, strings.Join(allowedChannels, ", "), tool.Description, tool.Help, tmpHelp)
-	if len(cmds) > 0 {
-		for j := 0; j < len(cmds); j++ {
-			if cmds[j] == "help" {
-				c.SendMessageToChannel(channel, cmdHelp)
-				return true
-			}
-		}
+	if isHelpRequest(cmds) {
+		c.SendMessageToChannel(channel, c.toolHelpText(tool))
+		return true
 	}
 
 	if !authorized {
-		c.sendConfigMessageToChannel(channel, "unauthorized", strings.Join(allowedChannels, ", "))
-		c.SendMessageToChannel(channel, cmdHelp)
-		c.logToChannel(channel, user, tool.Trigger+" "+strings.Join(cmds, " "))
+		c.sendConfigMessageToChannel(channel, "unauthorized", strings.Join(c.getChannelNames(tool.Permissions), ", "))
+		c.SendMessageToChannel(channel, c.toolHelpText(tool))
+		c.emitAuditEvent(thisUser, tool, channel, strings.Join(cmds, " "), "", -1, 0, "unauthorized")
 		return true
 	}
 
@@ -409,7 +555,6 @@ func (c *Client) processValidCommand(cmds []string, tool Tool, channel, user, ti
 		for j := range tool.Parameters {
 			log.Debug(" ----> Param Parameters[" + strconv.Itoa(j) + "]: " + tool.Parameters[j].Name)
 			derivedSource := tool.Parameters[j].Source
-			tmpHelp = fmt.Sprintf("%s\n%s: [%s%s]", tmpHelp, tool.Parameters[j].Name, strings.Join(tool.Parameters[j].Allowed, "|"), tool.Parameters[j].Description)
 			if len(derivedSource) > 0 {
 				log.Debug("Deriving allowed parameters: " + strings.Join(derivedSource, " "))
 				allowedOut := strings.Split(c.runShellCommands([]string{"bash", "-c", "cd " + tool.Location + " && " + strings.Join(derivedSource, " ")}), "\n")
@@ -419,7 +564,7 @@ func (c *Client) processValidCommand(cmds []string, tool Tool, channel, user, ti
 	}
 
 	if tool.Log {
-		c.logToChannel(channel, user, tool.Trigger+" "+strings.Join(cmds, " "))
+		c.emitAuditEvent(thisUser, tool, channel, strings.Join(cmds, " "), "", -1, 0, "invocation received")
 	}
 
 	// Validate parameters against whitelist
@@ -464,25 +609,44 @@ func (c *Client) processValidCommand(cmds []string, tool Tool, channel, user, ti
 			buildCmd = re.ReplaceAllString(buildCmd, cmds[x])
 		}
 	}
-	buildCmd = fmt.Sprintf(
-		"export TRIGGERED_AT=%s && export TRIGGERED_USER_ID=%s && export TRIGGERED_USER_NAME=%s && export TRIGGERED_CHANNEL_ID=%s && export TRIGGERED_CHANNEL_NAME=%s && cd %s && %s",
-		timestamp,
-		user,
-		thisUser.Name,
-		channel,
-		strings.Join(c.getChannelNames([]string{channel}), ""),
-		tool.Location,
-		buildCmd,
-	)
-	splitOn := regexp.MustCompile(`\s\&\&`)
-	displayCmd := splitOn.ReplaceAllString(buildCmd, " \\\n        &&")
+	env := []string{
+		"TRIGGERED_AT=" + timestamp,
+		"TRIGGERED_USER_ID=" + user,
+		"TRIGGERED_USER_NAME=" + thisUser.Name,
+		"TRIGGERED_CHANNEL_ID=" + channel,
+		"TRIGGERED_CHANNEL_NAME=" + strings.Join(c.getChannelNames([]string{channel}), ""),
+	}
+	for _, envvar := range tool.Envvars {
+		env = append(env, envvar+"="+os.Getenv(envvar))
+	}
+
+	sandbox, err := execution.New(tool.Sandbox.Backend)
+	if err != nil {
+		log.WithError(err).Errorf("invalid sandbox configuration for %q", tool.Trigger)
+		return false
+	}
+
+	displayCmd := fmt.Sprintf("[sandbox:%s] cd %s && %s", tool.Sandbox.Backend, tool.Location, buildCmd)
 	log.Info("Triggered Command:")
 	log.Info(displayCmd)
 
-	tmpCmd := []string{"bash", "-c", buildCmd}
-	var rawOutput = c.runShellCommands(tmpCmd)
 	// If the return string is more than 3500 characters, send it as a file
 	var fileThreshold = 3500
+	runStart := time.Now()
+	stdout, stderr, runErr := execution.RunCapped(sandbox.Build(ctx, tool.Sandbox, env, tool.Location, buildCmd), 0)
+	runDuration := time.Since(runStart)
+	rawOutput := stdout
+	if stderr != "" {
+		if rawOutput != "" {
+			rawOutput += "\n"
+		}
+		rawOutput += stderr
+	}
+	exitCode := 0
+	if runErr != nil {
+		rawOutput = fmt.Sprintf("error running command:\n%s\nerror: %s", buildCmd, runErr.Error())
+		exitCode = 1
+	}
 	log.Info("Return length:")
 	log.Info(len(rawOutput))
 	var sendFile = false
@@ -501,15 +665,27 @@ func (c *Client) processValidCommand(cmds []string, tool Tool, channel, user, ti
 	retFile += fmt.Sprintf(" ----> Command:\n%s\n", displayCmd)
 	retFile += rawOutput
 	var ret = rawOutput
+	isError := runErr != nil || stderr != ""
 	switch tool.Response {
 	case "file":
 		sendFile = true
 		ret = retFile
 	case "code":
 		ret = fmt.Sprintf("```%s```", rawOutput)
+	case "blocks":
+		// Attachments are never uploaded as a file, whatever their length.
+		sendFile = false
 	}
 	log.Debug(ret)
-	if sendFile {
+	if tool.Response == "blocks" {
+		attachment := buildAttachment(tool, rawOutput, isError)
+		if tool.Ephemeral {
+			c.sendConfigMessageToChannel(channel, "ephemeral", "")
+			c.SendAttachmentsToUser(channel, user, attachment)
+		} else {
+			c.SendAttachmentsToChannel(channel, attachment)
+		}
+	} else if sendFile {
 		var tFile = fmt.Sprintf("%s.txt", timestamp)
 		log.Info(tFile)
 		f, err := os.Create(tFile)
@@ -538,49 +714,43 @@ func (c *Client) processValidCommand(cmds []string, tool Tool, channel, user, ti
 		}
 	}
 	if tool.Log {
-		// c.logToChannel(channel, user, ret)
-
-		var tFile = fmt.Sprintf("bashbot-log-%s.txt", timestamp)
-		log.Info(tFile)
-		f, err := os.Create(tFile)
-		if err != nil {
-			log.Error(err)
-		}
-		defer f.Close()
-		_, err2 := f.WriteString(retFile)
-		if err2 != nil {
-			log.Error(err2)
-		}
-		uploadParams := slack.FileUploadParameters{
-			Channels: []string{c.cfg.Admins[0].LogChannelId},
-			File:     tFile,
-		}
-
-		if _, err := c.slackClient.UploadFile(uploadParams); err != nil {
-			log.Errorf("Unexpected error uploading file: %s", err)
-		}
+		c.emitAuditEvent(thisUser, tool, channel, strings.Join(cmds, " "), displayCmd, exitCode, runDuration, rawOutput)
 	}
 	return true
 }
 
-func (c *Client) logToChannel(channelID, userID, msg string) {
-	user, err := c.slackClient.GetUserInfo(userID)
-	if err != nil {
-		log.Errorf("can't get user: %v", err)
+// emitAuditEvent builds a structured audit.Event for a single tool
+// invocation and hands it to the configured audit.Logger backend
+// (slack channel, rotating JSON-lines file, or HTTPS webhook). user is
+// the invoking user, already looked up by the caller.
+//
+// Output is hashed rather than stored verbatim so the event stays small
+// and doesn't leak command output into whichever backend is configured.
+func (c *Client) emitAuditEvent(user *slack.User, tool Tool, channelID, rawArgs, resolvedCmd string, exitCode int, duration time.Duration, output string) {
+	if c.auditLogger == nil {
 		return
 	}
-	// Display message in chat-ops-log unless it came from admin channel
 	if channelID == c.cfg.Admins[0].PrivateChannelId {
 		return
 	}
-	channel := c.getChannelNames([]string{channelID})
-	retacks := regexp.MustCompile("`")
-	msg = retacks.ReplaceAllLiteralString(msg, "")
-	msg = truncateString(msg, 1000)
-	output := fmt.Sprintf("%s <@%s> <#%s> - %s", c.cfg.Admins[0].AppName, user.ID, channelID, msg)
-	c.SendMessageToChannel(c.cfg.Admins[0].LogChannelId, output)
-	log.Debugf("Bashbot command triggered channel: %s", channel)
-	log.Info(output)
+	channelNames := c.getChannelNames([]string{channelID})
+	event := audit.Event{
+		Time:        time.Now(),
+		UserID:      user.ID,
+		UserName:    user.Name,
+		UserEmail:   user.Profile.Email,
+		ChannelID:   channelID,
+		ChannelName: strings.Join(channelNames, ""),
+		Tool:        tool.Trigger,
+		RawArgs:     truncateString(rawArgs, 1000),
+		ResolvedCmd: resolvedCmd,
+		ExitCode:    exitCode,
+		Duration:    duration,
+		OutputHash:  fmt.Sprintf("%x", sha256.Sum256([]byte(output))),
+	}
+	if err := c.auditLogger.Log(event); err != nil {
+		log.Errorf("failed to emit audit event: %s", err)
+	}
 }
 
 // ConfigureLogger configures the logger used by bashbot to set the log level